@@ -0,0 +1,128 @@
+package netmerge
+
+import (
+	"math/big"
+	"net"
+)
+
+// Options configures MergeCIDRsWithBudget's aggregation policy. A zero
+// value places no limits at all, so each family fully collapses down to a
+// single CIDR — this is NOT the same as MergeCIDRs, which only ever merges
+// one closest pair per call. Set at least one field to bound the merge.
+type Options struct {
+	// MaxExtraIPs caps the total number of addresses a merge may introduce
+	// that weren't present in any input CIDR, across the whole call. Nil
+	// means unlimited.
+	MaxExtraIPs *big.Int
+	// MinPrefixLenV4 and MinPrefixLenV6 set the shortest prefix (i.e. the
+	// largest block) a merge is allowed to produce for each family; merges
+	// that would produce a shorter prefix are rejected. Zero means
+	// unlimited (up to /0).
+	MinPrefixLenV4 int
+	MinPrefixLenV6 int
+	// TargetCount stops merging a family once its vector count has dropped
+	// to this many entries; a family that starts at or below it is left
+	// unmerged. Zero means unlimited (merge until no legal merge remains).
+	TargetCount int
+}
+
+// CountIPsInCIDR returns the number of addresses contained in cidr as a
+// big.Int, since IPv6 blocks routinely exceed what a uint64 can hold.
+func CountIPsInCIDR(cidr string) (*big.Int, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	size, bits := ipNet.Mask.Size()
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-size)), nil
+}
+
+// vectorSize returns the number of addresses covered by v.
+func vectorSize(v IPVector) *big.Int {
+	size := new(big.Int).Sub(v.LastIP, v.FirstIP)
+	return size.Add(size, big.NewInt(1))
+}
+
+// MergeCIDRsWithBudget aggregates input CIDRs per address family, merging
+// the closest adjacent pair repeatedly until no legal merge remains. A
+// candidate merge that would violate opts is skipped and the remaining
+// pairs are still tried: no merge produces a prefix shorter than the
+// configured minimum, the merges introduce no more than MaxExtraIPs new
+// addresses in total, and no family is merged past TargetCount entries.
+// With a zero Options this fully collapses each family down to a single
+// CIDR.
+func MergeCIDRsWithBudget(input []string, opts Options) (out []string, err error) {
+	v4, v6, passthrough, err := partitionByFamily(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// One copy of the extra-address budget is shared by both families.
+	var budget *big.Int
+	if opts.MaxExtraIPs != nil {
+		budget = new(big.Int).Set(opts.MaxExtraIPs)
+	}
+
+	merged, err := mergeWithinBudget(v4, opts.MinPrefixLenV4, opts.TargetCount, budget)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, merged...)
+
+	merged, err = mergeWithinBudget(v6, opts.MinPrefixLenV6, opts.TargetCount, budget)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, merged...)
+
+	out = append(out, passthrough...)
+	return out, nil
+}
+
+// mergeWithinBudget repeatedly merges the closest adjacent pair of
+// same-family vectors, skipping every candidate merge that would violate
+// minPrefixLen, maxExtraIPs or targetCount. A non-nil maxExtraIPs is
+// reduced in place by each accepted merge.
+func mergeWithinBudget(vectors []IPVector, minPrefixLen, targetCount int, maxExtraIPs *big.Int) ([]string, error) {
+	remaining := maxExtraIPs
+	count := len(vectors)
+
+	merged, err := nearestNeighborMerge(vectors, func(merged, v1, v2 IPVector) bool {
+		if targetCount > 0 && count <= targetCount {
+			return false
+		}
+
+		prefixLen, _ := merged.CIDR.Mask.Size()
+		if minPrefixLen > 0 && prefixLen < minPrefixLen {
+			return false
+		}
+
+		extra := new(big.Int).Sub(vectorSize(merged), new(big.Int).Add(vectorSize(v1), vectorSize(v2)))
+		// Addresses that v1 and v2 share are counted once.
+		lo, hi := v1.FirstIP, v1.LastIP
+		if v2.FirstIP.Cmp(lo) > 0 {
+			lo = v2.FirstIP
+		}
+		if v2.LastIP.Cmp(hi) < 0 {
+			hi = v2.LastIP
+		}
+		if hi.Cmp(lo) >= 0 {
+			extra.Add(extra, new(big.Int).Sub(hi, lo))
+			extra.Add(extra, big.NewInt(1))
+		}
+		if remaining != nil {
+			if extra.Cmp(remaining) > 0 {
+				return false
+			}
+			remaining.Sub(remaining, extra)
+		}
+
+		count--
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vectorsToCIDRs(merged), nil
+}
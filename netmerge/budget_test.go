@@ -0,0 +1,58 @@
+package netmerge
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMergeCIDRsWithBudgetSkipsIllegalPairWithoutAbortingRestOfFamily(t *testing.T) {
+	in := []string{"10.0.0.127/32", "10.0.0.128/32", "20.0.0.0/26", "20.0.0.64/26"}
+	out, err := MergeCIDRsWithBudget(in, Options{MinPrefixLenV4: 25})
+	if err != nil {
+		t.Fatalf("MergeCIDRsWithBudget: %v", err)
+	}
+
+	// 10.0.0.127/32 + 10.0.0.128/32 straddle a byte boundary and can only
+	// merge into a /24, shorter than /25, so that pair must be
+	// rejected and left as-is. 20.0.0.0/26 + 20.0.0.64/26 are a legal,
+	// aligned /25 merge and must not be skipped just because a smaller,
+	// illegal pair was tried (and rejected) first.
+	want := []string{"10.0.0.127/32", "10.0.0.128/32", "20.0.0.0/25"}
+	sort.Strings(out)
+	sort.Strings(want)
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("MergeCIDRsWithBudget(%v) = %v, want %v", in, out, want)
+	}
+}
+
+func TestMergeCIDRsWithBudgetZeroValueFullyCollapses(t *testing.T) {
+	in := []string{"10.0.0.0/24", "192.168.1.0/24", "8.8.8.0/24"}
+	out, err := MergeCIDRsWithBudget(in, Options{})
+	if err != nil {
+		t.Fatalf("MergeCIDRsWithBudget: %v", err)
+	}
+
+	// Documented behavior: a zero Options places no limits at all, so the
+	// whole family collapses into one CIDR, unlike MergeCIDRs which only
+	// ever merges a single closest pair.
+	want := []string{"8.8.8.0/0"}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("MergeCIDRsWithBudget(%v, Options{}) = %v, want %v", in, out, want)
+	}
+}
+
+func TestMergeCIDRsWithBudgetTargetCount(t *testing.T) {
+	in := []string{"10.0.0.0/25", "10.0.0.128/25"}
+	out, err := MergeCIDRsWithBudget(in, Options{TargetCount: 2})
+	if err != nil {
+		t.Fatalf("MergeCIDRsWithBudget: %v", err)
+	}
+
+	want := []string{"10.0.0.0/25", "10.0.0.128/25"}
+	sort.Strings(out)
+	sort.Strings(want)
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("MergeCIDRsWithBudget(%v, TargetCount: 2) = %v, want %v unmerged", in, out, want)
+	}
+}
@@ -0,0 +1,92 @@
+package netmerge
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// ipRange is a closed [Start,End] address interval used by the lossless
+// aggregation and set-algebra helpers in this package.
+type ipRange struct {
+	Family int
+	Start  *big.Int
+	End    *big.Int
+}
+
+// CoalesceCIDRs merges only CIDRs that are adjacent or overlapping,
+// returning the minimal exact CIDR set that covers the same addresses as
+// the input. Unlike MergeCIDRs, which clusters nearby-but-disjoint ranges
+// into a single larger (lossy) CIDR, CoalesceCIDRs never introduces an
+// address that wasn't present in the input.
+func CoalesceCIDRs(input []string) (out []string, err error) {
+	v4, v6, passthrough, err := partitionByFamily(input)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vectors := range [][]IPVector{v4, v6} {
+		if len(vectors) == 0 {
+			continue
+		}
+		for _, r := range coalesceRanges(vectors) {
+			out = append(out, rangeToCIDRs(r)...)
+		}
+	}
+
+	out = append(out, passthrough...)
+	return out, nil
+}
+
+// coalesceRanges sorts vectors by FirstIP and sweeps them into the minimal
+// set of disjoint [start,end] intervals, unioning any ranges that overlap
+// or touch (i.e. one ends exactly where the next begins).
+func coalesceRanges(vectors []IPVector) []ipRange {
+	sorted := make([]IPVector, len(vectors))
+	copy(sorted, vectors)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].FirstIP.Cmp(sorted[j].FirstIP) < 0
+	})
+
+	ranges := []ipRange{{Family: sorted[0].Family, Start: sorted[0].FirstIP, End: sorted[0].LastIP}}
+	for _, v := range sorted[1:] {
+		last := &ranges[len(ranges)-1]
+		if v.FirstIP.Cmp(new(big.Int).Add(last.End, big.NewInt(1))) <= 0 {
+			if v.LastIP.Cmp(last.End) > 0 {
+				last.End = v.LastIP
+			}
+			continue
+		}
+		ranges = append(ranges, ipRange{Family: v.Family, Start: v.FirstIP, End: v.LastIP})
+	}
+	return ranges
+}
+
+// rangeToCIDRs decomposes a [start,end] range into the minimal set of
+// aligned CIDR blocks: repeatedly take the largest block whose start is
+// aligned to that block size and which doesn't run past end.
+func rangeToCIDRs(r ipRange) []string {
+	bits := bitWidth(r.Family)
+	var out []string
+
+	start := new(big.Int).Set(r.Start)
+	for start.Cmp(r.End) <= 0 {
+		size := 0
+		var blockLen uint
+		for ; size <= bits; size++ {
+			blockLen = uint(bits - size)
+			if new(big.Int).And(start, onesMask(int(blockLen))).Sign() != 0 {
+				continue // start isn't aligned to a block of this size
+			}
+			blockEnd := new(big.Int).Add(start, onesMask(int(blockLen)))
+			if blockEnd.Cmp(r.End) > 0 {
+				continue // block would run past the end of the range
+			}
+			break
+		}
+
+		out = append(out, fmt.Sprintf("%s/%d", bigIntToIP(start, r.Family), size))
+		start = new(big.Int).Add(start, new(big.Int).Lsh(big.NewInt(1), blockLen))
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package netmerge
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCoalesceCIDRsAdjacentAndOverlapping(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{
+			name: "adjacent blocks merge losslessly",
+			in:   []string{"10.0.0.0/25", "10.0.0.128/25"},
+			want: []string{"10.0.0.0/24"},
+		},
+		{
+			name: "overlapping blocks merge",
+			in:   []string{"10.0.0.0/24", "10.0.0.128/25"},
+			want: []string{"10.0.0.0/24"},
+		},
+		{
+			name: "disjoint blocks are left apart, unlike MergeCIDRs",
+			in:   []string{"10.0.0.0/24", "8.8.8.0/24"},
+			want: []string{"10.0.0.0/24", "8.8.8.0/24"},
+		},
+		{
+			name: "mixed families coalesce independently",
+			in:   []string{"10.0.0.0/25", "10.0.0.128/25", "fe80::/65", "fe80:0:0:0:8000::/65"},
+			want: []string{"10.0.0.0/24", "fe80::/64"},
+		},
+		{
+			name: "non-CIDR entries pass through unchanged",
+			in:   []string{"10.0.0.0/25", "10.0.0.128/25", "not-a-cidr"},
+			want: []string{"10.0.0.0/24", "not-a-cidr"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CoalesceCIDRs(tt.in)
+			if err != nil {
+				t.Fatalf("CoalesceCIDRs(%v): %v", tt.in, err)
+			}
+			sort.Strings(got)
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("CoalesceCIDRs(%v) = %v, want %v", tt.in, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package netmerge
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// mergeNode is a linked-list node used by nearestNeighborMerge: each node
+// wraps one IPVector and points at its current left/right neighbor in
+// FirstIP order, so a merge only has to touch the two neighboring
+// pair-distances instead of rescanning every pair.
+type mergeNode struct {
+	vector     IPVector
+	prev, next *mergeNode
+	alive      bool
+}
+
+// pairItem is one entry in the nearest-neighbor heap: the distance between
+// a node and its current right-hand neighbor.
+type pairItem struct {
+	left, right *mergeNode
+	dist        float64
+}
+
+// pairHeap is a min-heap of pairItem ordered by dist.
+type pairHeap []*pairItem
+
+func (h pairHeap) Len() int            { return len(h) }
+func (h pairHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h pairHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *pairHeap) Push(x interface{}) { *h = append(*h, x.(*pairItem)) }
+func (h *pairHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// nearestNeighborMerge repeatedly merges the closest adjacent pair of
+// same-family vectors using a min-heap of adjacent-pair distances, instead
+// of rescanning every pair on every iteration the way closestVectors does.
+// Vectors are first sorted by FirstIP and only pairs that are neighbours in
+// that order are ever compared, so when vectors overlap, a closer pair that
+// is not adjacent in that order is not merged first. Each merge only
+// touches the two neighboring heap entries. That drops the overall cost
+// from the O(n^3) of repeatedly calling closestVectors to O(n log n).
+//
+// accept is consulted before each merge is committed; when it returns
+// false that pair is left unmerged and the loop moves on to the next
+// closest pair still in the heap, with a nil accept meaning "always
+// merge".
+func nearestNeighborMerge(vectors []IPVector, accept func(merged, v1, v2 IPVector) bool) ([]IPVector, error) {
+	if len(vectors) < 2 {
+		return vectors, nil
+	}
+
+	nodes := make([]*mergeNode, len(vectors))
+	for i, v := range sortedByFirstIP(vectors) {
+		nodes[i] = &mergeNode{vector: v, alive: true}
+	}
+	for i := 0; i < len(nodes)-1; i++ {
+		nodes[i].next = nodes[i+1]
+		nodes[i+1].prev = nodes[i]
+	}
+	head := nodes[0]
+
+	h := &pairHeap{}
+	heap.Init(h)
+	for i := 0; i < len(nodes)-1; i++ {
+		heap.Push(h, &pairItem{left: nodes[i], right: nodes[i+1], dist: distance(nodes[i].vector, nodes[i+1].vector)})
+	}
+
+	alive := len(nodes)
+	for h.Len() > 0 && alive > 1 {
+		item := heap.Pop(h).(*pairItem)
+		if !item.left.alive || !item.right.alive || item.left.next != item.right {
+			continue // stale entry: one side has already been merged away
+		}
+
+		merged, err := mergeIPNets(&item.left.vector, &item.right.vector)
+		if err != nil {
+			return nil, err
+		}
+		if accept != nil && !accept(merged, item.left.vector, item.right.vector) {
+			continue // leave this pair unmerged, try the next closest pair
+		}
+
+		newNode := &mergeNode{vector: merged, alive: true, prev: item.left.prev, next: item.right.next}
+		item.left.alive = false
+		item.right.alive = false
+
+		if newNode.prev != nil {
+			newNode.prev.next = newNode
+			heap.Push(h, &pairItem{left: newNode.prev, right: newNode, dist: distance(newNode.prev.vector, newNode.vector)})
+		} else {
+			head = newNode
+		}
+		if newNode.next != nil {
+			newNode.next.prev = newNode
+			heap.Push(h, &pairItem{left: newNode, right: newNode.next, dist: distance(newNode.vector, newNode.next.vector)})
+		}
+		alive--
+	}
+
+	var out []IPVector
+	for n := head; n != nil; n = n.next {
+		out = append(out, n.vector)
+	}
+	return out, nil
+}
+
+// sortedByFirstIP returns a copy of vectors sorted by FirstIP.
+func sortedByFirstIP(vectors []IPVector) []IPVector {
+	out := make([]IPVector, len(vectors))
+	copy(out, vectors)
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].FirstIP.Cmp(out[j].FirstIP) < 0
+	})
+	return out
+}
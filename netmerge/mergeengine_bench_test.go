@@ -0,0 +1,66 @@
+package netmerge
+
+import (
+	"fmt"
+	"testing"
+)
+
+// legacyMergeFamily collapses a family by calling closestVectors over and
+// over (a full pairwise scan per merge) purely so BenchmarkMergeFamily can
+// measure the improvement from nearestNeighborMerge.
+func legacyMergeFamily(vectors []IPVector) ([]string, error) {
+	for len(vectors) > 1 {
+		v1, v2, err := closestVectors(&vectors)
+		if err != nil {
+			return nil, err
+		}
+		newRange, err := mergeIPNets(&v1, &v2)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, newRange)
+	}
+	return vectorsToCIDRs(vectors), nil
+}
+
+// benchVectors builds n consecutive, non-overlapping /32 IPv4 vectors
+// starting at 0.0.0.0.
+func benchVectors(n int) []IPVector {
+	vectors := make([]IPVector, n)
+	for i := 0; i < n; i++ {
+		cidr := fmt.Sprintf("%d.%d.%d.%d/32", byte(i>>24), byte(i>>16), byte(i>>8), byte(i))
+		v, err := cidrToVector(cidr)
+		if err != nil {
+			panic(err)
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
+
+func BenchmarkMergeFamilyLegacy(b *testing.B) {
+	vectors := benchVectors(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		input := make([]IPVector, len(vectors))
+		copy(input, vectors)
+		if _, err := legacyMergeFamily(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMergeFamily exercises nearestNeighborMerge directly (rather than
+// mergeFamily, which now only merges a single closest pair) since the heap
+// is where the O(n log n) improvement over legacyMergeFamily actually lives.
+func BenchmarkMergeFamily(b *testing.B) {
+	vectors := benchVectors(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		input := make([]IPVector, len(vectors))
+		copy(input, vectors)
+		if _, err := nearestNeighborMerge(input, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package netmerge
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func mustVectors(t *testing.T, cidrs ...string) []IPVector {
+	t.Helper()
+	vectors := make([]IPVector, len(cidrs))
+	for i, c := range cidrs {
+		v, err := cidrToVector(c)
+		if err != nil {
+			t.Fatalf("cidrToVector(%q): %v", c, err)
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
+
+func TestNearestNeighborMergeFullyCollapsesWithNilAccept(t *testing.T) {
+	vectors := mustVectors(t, "10.0.0.0/32", "10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32")
+
+	merged, err := nearestNeighborMerge(vectors, nil)
+	if err != nil {
+		t.Fatalf("nearestNeighborMerge: %v", err)
+	}
+
+	got := vectorsToCIDRs(merged)
+	want := []string{"10.0.0.0/30"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nearestNeighborMerge(%v, nil) = %v, want %v", vectors, got, want)
+	}
+}
+
+func TestNearestNeighborMergeRejectsOnlyTheOffendingPair(t *testing.T) {
+	// Two tight clusters of four /32 hosts each, 256 addresses apart. Each cluster's internal
+	// merges stay within a /30 and must go through; only the final
+	// cross-cluster merge (which would produce a /23) should be rejected.
+	vectors := mustVectors(t,
+		"10.0.0.0/32", "10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32",
+		"10.0.1.0/32", "10.0.1.1/32", "10.0.1.2/32", "10.0.1.3/32",
+	)
+
+	merged, err := nearestNeighborMerge(vectors, func(merged, v1, v2 IPVector) bool {
+		prefixLen, _ := merged.CIDR.Mask.Size()
+		return prefixLen >= 24
+	})
+	if err != nil {
+		t.Fatalf("nearestNeighborMerge: %v", err)
+	}
+
+	got := vectorsToCIDRs(merged)
+	sort.Strings(got)
+	want := []string{"10.0.0.0/30", "10.0.1.0/30"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nearestNeighborMerge(%v, minPrefix 24) = %v, want %v (clusters merged, cross-cluster merge rejected)", vectors, got, want)
+	}
+}
+
+func TestNearestNeighborMergeSingleVectorIsNoop(t *testing.T) {
+	vectors := mustVectors(t, "10.0.0.0/24")
+
+	merged, err := nearestNeighborMerge(vectors, nil)
+	if err != nil {
+		t.Fatalf("nearestNeighborMerge: %v", err)
+	}
+
+	got := vectorsToCIDRs(merged)
+	want := []string{"10.0.0.0/24"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nearestNeighborMerge(%v, nil) = %v, want %v unchanged", vectors, got, want)
+	}
+}
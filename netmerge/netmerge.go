@@ -1,51 +1,81 @@
 package netmerge
 
 import (
-	"encoding/binary"
 	"fmt"
-	"math"
+	"math/big"
 	"net"
 	"strings"
 )
 
-type IPv4Vector struct {
-	FirstIP uint32
-	LastIP  uint32
+// IPVector represents a contiguous range of IP addresses, bounded by the
+// smallest CIDR block that currently contains it. Family is 4 or 6 and
+// determines the address width (32 or 128 bits) used by the arithmetic
+// helpers below, so the same type and the same merge logic work for both
+// IPv4 and IPv6 vectors.
+type IPVector struct {
+	Family  int
+	FirstIP *big.Int
+	LastIP  *big.Int
 	CIDR    net.IPNet
 }
 
-// Parses input string and stores first IP, last IP and CIDR to IPv4Vector type
-func cidrToVector(cidr string) (vector IPv4Vector, err error) {
+// IPv4Vector is kept as an alias of IPVector for API compatibility with
+// earlier, IPv4-only versions of netmerge.
+type IPv4Vector = IPVector
+
+// bitWidth returns the address width in bits for the given family.
+func bitWidth(family int) int {
+	if family == 6 {
+		return 128
+	}
+	return 32
+}
+
+// onesMask returns a big.Int with its lowest `bits` bits set to 1.
+func onesMask(bits int) *big.Int {
+	m := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	return m.Sub(m, big.NewInt(1))
+}
+
+// Parses input string and stores first IP, last IP and CIDR to IPVector
+// type. Works for both IPv4 and IPv6 CIDRs.
+func cidrToVector(cidr string) (vector IPVector, err error) {
 	ip, ipNet, err := net.ParseCIDR(cidr)
 	if err != nil {
 		return vector, err
 	}
 
-	// Convert IP address to uint32
-	ipUint := binary.BigEndian.Uint32(ip.To4())
+	family := 4
+	raw := ip.To4()
+	if raw == nil {
+		family = 6
+		raw = ip.To16()
+	}
 
-	// Calculate the last IP address in the CIDR block
-	mask := binary.BigEndian.Uint32(ipNet.Mask)
-	lastIP := (ipUint & mask) | (mask ^ 0xffffffff)
+	ipInt := new(big.Int).SetBytes(raw)
+	maskInt := new(big.Int).SetBytes(ipNet.Mask)
+	bits := bitWidth(family)
+	invertedMask := new(big.Int).Xor(maskInt, onesMask(bits))
+	lastIP := new(big.Int).Or(ipInt, invertedMask)
 
-	vector = IPv4Vector{ipUint, lastIP, *ipNet}
-	return vector, err
+	vector = IPVector{Family: family, FirstIP: ipInt, LastIP: lastIP, CIDR: *ipNet}
+	return vector, nil
 }
 
-// Searches for closest IP ranges
-func closestVectors(in *[]IPv4Vector) (closest1, closest2 IPv4Vector, err error) {
+// Searches for closest IP ranges among same-family vectors.
+func closestVectors(in *[]IPVector) (closest1, closest2 IPVector, err error) {
 
 	var firstIndex, lastIndex int
 	if len(*in) < 2 {
-		return IPv4Vector{}, IPv4Vector{}, fmt.Errorf("[ERR]: Vectors number should be >2")
+		return IPVector{}, IPVector{}, fmt.Errorf("[ERR]: Vectors number should be >2")
 	}
 
-	closestDist := math.Inf(1)
+	closestDist := -1.0
 
 	for i := 0; i < len(*in)-1; i++ {
 		for j := i + 1; j < len(*in); j++ {
-			dist := float64(distance((*in)[i], (*in)[j]))
-			if dist < closestDist {
+			dist := distance((*in)[i], (*in)[j])
+			if closestDist < 0 || dist < closestDist {
 				closest1 = (*in)[i]
 				closest2 = (*in)[j]
 				closestDist = dist
@@ -67,41 +97,39 @@ func closestVectors(in *[]IPv4Vector) (closest1, closest2 IPv4Vector, err error)
 	return closest1, closest2, nil
 }
 
-// Calculate distance between two Vectors
-func distance(v1, v2 IPv4Vector) uint32 {
-	var minIP, maxIP uint32
-	if v1.FirstIP > v2.FirstIP {
-		minIP = v1.FirstIP - v2.FirstIP
-	} else {
-		minIP = v2.FirstIP - v1.FirstIP
-	}
+// Calculate distance between two Vectors, using arbitrary-precision
+// arithmetic so the same metric works for IPv4 and IPv6 alike.
+func distance(v1, v2 IPVector) float64 {
+	minDiff := new(big.Int).Sub(v1.FirstIP, v2.FirstIP)
+	minDiff.Abs(minDiff)
+	maxDiff := new(big.Int).Sub(v1.LastIP, v2.LastIP)
+	maxDiff.Abs(maxDiff)
+
+	minF := new(big.Float).SetInt(minDiff)
+	maxF := new(big.Float).SetInt(maxDiff)
+	sum, _ := new(big.Float).Add(minF, maxF).Float64()
+	return sum
+}
 
-	if v1.LastIP > v2.LastIP {
-		maxIP = v1.LastIP - v2.LastIP
-	} else {
-		maxIP = v2.LastIP - v1.LastIP
+// Searches for a smallest a largest IPs and tries to calculate the mask
+// that bounds them. Both vectors must belong to the same address family.
+func mergeIPNets(v1, v2 *IPVector) (out IPVector, err error) {
+	if v1.Family != v2.Family {
+		return out, fmt.Errorf("[ERR]: cannot merge vectors of different address families")
 	}
-	return uint32(float64(int32(minIP))) +
-		uint32(float64(int32(maxIP)))
-}
 
-// Searches for a smallest a largest IPs in uint32 and tries to calulate mask for them
-func mergeIPNets(v1, v2 *IPv4Vector) (out IPv4Vector, err error) {
-	var minIP, maxIP uint32
-	if v1.FirstIP > v2.FirstIP {
+	minIP := v1.FirstIP
+	if v2.FirstIP.Cmp(minIP) < 0 {
 		minIP = v2.FirstIP
-	} else {
-		minIP = v1.FirstIP
 	}
-
-	if v1.LastIP > v2.LastIP {
-		maxIP = v1.LastIP
-	} else {
+	maxIP := v1.LastIP
+	if v2.LastIP.Cmp(maxIP) > 0 {
 		maxIP = v2.LastIP
 	}
 
-	newMask := 32 - countDifferentBits(minIP, maxIP)
-	newIP := binaryToIP(minIP).To4()
+	bits := bitWidth(v1.Family)
+	newMask := bits - countDifferentBits(minIP, maxIP, bits)
+	newIP := bigIntToIP(minIP, v1.Family)
 	newCIDR := fmt.Sprintf("%s/%d", newIP, newMask)
 
 	out, err = cidrToVector(newCIDR)
@@ -109,65 +137,107 @@ func mergeIPNets(v1, v2 *IPv4Vector) (out IPv4Vector, err error) {
 	return out, err
 }
 
-// Search for a first different bit, starting from higher bit
-func countDifferentBits(num1, num2 uint32) int {
-	// Convert the numbers to binary strings
-	bin1 := fmt.Sprintf("%032b", num1)
-	bin2 := fmt.Sprintf("%032b", num2)
-
-	// Compare the binary strings bit by bit
-	for i := 0; i < 32; i++ {
-		if bin1[i] != bin2[i] {
-			// Count the number of bits left till the end of the input
-			return 32 - i
+// Search for the first different bit, starting from the most significant
+// bit of a `bits`-wide address.
+func countDifferentBits(num1, num2 *big.Int, bits int) int {
+	for i := bits - 1; i >= 0; i-- {
+		if num1.Bit(i) != num2.Bit(i) {
+			return i + 1
 		}
 	}
-
-	// If all bits match, return 0
 	return 0
 }
 
-// Converts uint32 to net.IP format string
-func binaryToIP(ip uint32) net.IP {
-	return net.IPv4(byte(ip>>24), byte(ip>>16), byte(ip>>8), byte(ip))
-}
-
-// Converts uint32 to IPv4 format string
-func uint32ToIP(ip uint32) string {
-	return fmt.Sprintf("%d.%d.%d.%d", byte(ip>>24), byte(ip>>16), byte(ip>>8), byte(ip))
+// Converts a big.Int address value back to a net.IP for the given family.
+func bigIntToIP(ip *big.Int, family int) net.IP {
+	size := 4
+	if family == 6 {
+		size = 16
+	}
+	buf := make([]byte, size)
+	raw := ip.Bytes()
+	copy(buf[size-len(raw):], raw)
+	return net.IP(buf)
 }
 
-// Merges input CIDRs to specified maxIpNum value
-func MergeCIDRs(input []string, maxIpNum uint8) (out []string, err error) {
-	var vectors []IPv4Vector
+// partitionByFamily parses input CIDRs into separate IPv4 and IPv6 vector
+// slices. Entries that aren't CIDRs are returned unchanged in passthrough.
+func partitionByFamily(input []string) (v4, v6 []IPVector, passthrough []string, err error) {
 	for _, i := range input {
 		if !strings.Contains(i, "/") {
-			out = append(out, i)
+			passthrough = append(passthrough, i)
+			continue
+		}
+		v, err := cidrToVector(i)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if v.Family == 6 {
+			v6 = append(v6, v)
 		} else {
-			v, err := cidrToVector(i)
-			if err != nil {
-				return []string{}, err
-			} else {
-				vectors = append(vectors, v)
-			}
+			v4 = append(v4, v)
 		}
 	}
+	return v4, v6, passthrough, nil
+}
+
+// vectorsToCIDRs renders a slice of vectors back to their textual CIDR form.
+func vectorsToCIDRs(vectors []IPVector) []string {
+	var out []string
+	for _, v := range vectors {
+		mask, _ := v.CIDR.Mask.Size()
+		out = append(out, fmt.Sprintf("%s/%d", bigIntToIP(v.FirstIP, v.Family), mask))
+	}
+	return out
+}
+
+// mergeFamily merges the single closest pair of same-family vectors,
+// leaving the rest untouched, mirroring MergeCIDRs' original one-pair-at-a-
+// time behaviour.
+func mergeFamily(vectors []IPVector) ([]string, error) {
+	if len(vectors) < 2 {
+		return vectorsToCIDRs(vectors), nil
+	}
 
-	var newRange IPv4Vector
 	v1, v2, err := closestVectors(&vectors)
+	if err != nil {
+		return nil, err
+	}
+	newRange, err := mergeIPNets(&v1, &v2)
+	if err != nil {
+		return nil, err
+	}
+	vectors = append(vectors, newRange)
+
+	return vectorsToCIDRs(vectors), nil
+}
+
+// Merges input CIDRs, merging the single closest pair of same-family
+// vectors and leaving the rest unchanged. IPv4 and IPv6 entries are
+// partitioned and merged independently, and non-CIDR entries are passed
+// through unchanged.
+//
+// maxIpNum is currently unused: it can't express real limits (a /8 already
+// covers 16M addresses). Use MergeCIDRsWithBudget if you need to cap the
+// merge instead of the baseline single-pair behaviour.
+func MergeCIDRs(input []string, maxIpNum uint8) (out []string, err error) {
+	v4, v6, passthrough, err := partitionByFamily(input)
 	if err != nil {
 		return []string{}, err
 	}
-	newRange, err = mergeIPNets(&v1, &v2)
+
+	merged, err := mergeFamily(v4)
 	if err != nil {
 		return []string{}, err
 	}
-	vectors = append(vectors, newRange)
+	out = append(out, merged...)
 
-	for _, v := range vectors {
-		ip := uint32ToIP(v.FirstIP)
-		mask, _ := v.CIDR.Mask.Size()
-		out = append(out, fmt.Sprintf("%s/%d", ip, mask))
+	merged, err = mergeFamily(v6)
+	if err != nil {
+		return []string{}, err
 	}
-	return out, err
+	out = append(out, merged...)
+
+	out = append(out, passthrough...)
+	return out, nil
 }
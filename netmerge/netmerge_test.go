@@ -0,0 +1,53 @@
+package netmerge
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMergeCIDRsMergesOnlyClosestPair(t *testing.T) {
+	in := []string{"10.0.0.0/24", "192.168.1.0/24", "8.8.8.0/24"}
+	out, err := MergeCIDRs(in, 10)
+	if err != nil {
+		t.Fatalf("MergeCIDRs: %v", err)
+	}
+
+	// Only the closest pair (10.0.0.0/24 and 8.8.8.0/24, the two nearest by
+	// the distance metric) should be merged; 192.168.1.0/24 must survive
+	// untouched, and the result must not collapse into a supernet covering
+	// unrelated ranges.
+	sort.Strings(out)
+	for _, cidr := range out {
+		if cidr == "0.0.0.0/0" {
+			t.Fatalf("MergeCIDRs(%v) collapsed into a full supernet: %v", in, out)
+		}
+	}
+	found192 := false
+	for _, cidr := range out {
+		if cidr == "192.168.1.0/24" {
+			found192 = true
+		}
+	}
+	if !found192 {
+		t.Errorf("MergeCIDRs(%v) = %v, want 192.168.1.0/24 left unmerged", in, out)
+	}
+	if len(out) != 2 {
+		t.Errorf("MergeCIDRs(%v) = %v, want exactly 2 CIDRs (one merged pair + one untouched)", in, out)
+	}
+}
+
+func TestMergeCIDRsSingleFamilyMember(t *testing.T) {
+	in := []string{"10.0.0.0/24", "fe80::/64"}
+	out, err := MergeCIDRs(in, 10)
+	if err != nil {
+		t.Fatalf("MergeCIDRs: %v", err)
+	}
+
+	want := []string{"10.0.0.0/24", "fe80::/64"}
+	sort.Strings(out)
+	sort.Strings(want)
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("MergeCIDRs(%v) = %v, want %v unchanged (nothing to pair within either family)", in, out, want)
+	}
+}
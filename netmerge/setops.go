@@ -0,0 +1,119 @@
+package netmerge
+
+import "math/big"
+
+// Diff returns the addresses present in a but not in b, as a minimal CIDR
+// list.
+func Diff(a, b []string) ([]string, error) {
+	return setOp(a, b, diffRanges)
+}
+
+// Intersect returns the addresses present in both a and b, as a minimal
+// CIDR list.
+func Intersect(a, b []string) ([]string, error) {
+	return setOp(a, b, intersectRanges)
+}
+
+// Exclude removes every address in holes from input, returning what's left
+// as a minimal CIDR list. It reads better than Diff at call sites doing
+// "merge these feeds but carve out our own ranges".
+func Exclude(input []string, holes []string) ([]string, error) {
+	return Diff(input, holes)
+}
+
+// setOp converts a and b to sorted, non-overlapping per-family ranges,
+// applies op to each family independently, and renders the result back to
+// CIDRs. Non-CIDR entries in a are passed through unchanged, matching
+// MergeCIDRs and CoalesceCIDRs.
+func setOp(a, b []string, op func(a, b []ipRange) []ipRange) ([]string, error) {
+	aV4, aV6, aPassthrough, err := toRanges(a)
+	if err != nil {
+		return nil, err
+	}
+	bV4, bV6, _, err := toRanges(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, r := range op(aV4, bV4) {
+		out = append(out, rangeToCIDRs(r)...)
+	}
+	for _, r := range op(aV6, bV6) {
+		out = append(out, rangeToCIDRs(r)...)
+	}
+	out = append(out, aPassthrough...)
+	return out, nil
+}
+
+// toRanges parses input into sorted, non-overlapping [start,end] ranges per
+// address family, reusing the same sweep CoalesceCIDRs uses. Entries that
+// aren't CIDRs are returned unchanged in passthrough.
+func toRanges(input []string) (v4, v6 []ipRange, passthrough []string, err error) {
+	v4Vectors, v6Vectors, passthrough, err := partitionByFamily(input)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(v4Vectors) > 0 {
+		v4 = coalesceRanges(v4Vectors)
+	}
+	if len(v6Vectors) > 0 {
+		v6 = coalesceRanges(v6Vectors)
+	}
+	return v4, v6, passthrough, nil
+}
+
+// diffRanges returns the portions of a that aren't covered by any range in
+// b.
+func diffRanges(a, b []ipRange) []ipRange {
+	remaining := make([]ipRange, len(a))
+	copy(remaining, a)
+
+	for _, hole := range b {
+		var next []ipRange
+		for _, r := range remaining {
+			next = append(next, subtractRange(r, hole)...)
+		}
+		remaining = next
+	}
+	return remaining
+}
+
+// subtractRange removes hole from r, returning zero, one or two surviving
+// sub-ranges depending on how much of r hole overlaps.
+func subtractRange(r, hole ipRange) []ipRange {
+	if hole.End.Cmp(r.Start) < 0 || hole.Start.Cmp(r.End) > 0 {
+		return []ipRange{r}
+	}
+
+	var out []ipRange
+	if hole.Start.Cmp(r.Start) > 0 {
+		out = append(out, ipRange{Family: r.Family, Start: r.Start, End: new(big.Int).Sub(hole.Start, big.NewInt(1))})
+	}
+	if hole.End.Cmp(r.End) < 0 {
+		out = append(out, ipRange{Family: r.Family, Start: new(big.Int).Add(hole.End, big.NewInt(1)), End: r.End})
+	}
+	return out
+}
+
+// intersectRanges returns the overlap between two sorted, non-overlapping
+// range sets.
+func intersectRanges(a, b []ipRange) []ipRange {
+	var out []ipRange
+	for _, ra := range a {
+		for _, rb := range b {
+			start := ra.Start
+			if rb.Start.Cmp(start) > 0 {
+				start = rb.Start
+			}
+			end := ra.End
+			if rb.End.Cmp(end) < 0 {
+				end = rb.End
+			}
+			if start.Cmp(end) <= 0 {
+				out = append(out, ipRange{Family: ra.Family, Start: start, End: end})
+			}
+		}
+	}
+	return out
+}
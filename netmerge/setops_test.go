@@ -0,0 +1,134 @@
+package netmerge
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func sortedOrNil(in []string) []string {
+	out := append([]string(nil), in...)
+	sort.Strings(out)
+	return out
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []string
+	}{
+		{
+			name: "carve a hole out of a larger block",
+			a:    []string{"10.0.0.0/24"},
+			b:    []string{"10.0.0.128/25"},
+			want: []string{"10.0.0.0/25"},
+		},
+		{
+			name: "no overlap leaves a untouched",
+			a:    []string{"10.0.0.0/24"},
+			b:    []string{"8.8.8.0/24"},
+			want: []string{"10.0.0.0/24"},
+		},
+		{
+			name: "full overlap empties a",
+			a:    []string{"10.0.0.0/25"},
+			b:    []string{"10.0.0.0/24"},
+			want: nil,
+		},
+		{
+			name: "mixed families",
+			a:    []string{"10.0.0.0/24", "fe80::/64"},
+			b:    []string{"10.0.0.0/25"},
+			want: []string{"10.0.0.128/25", "fe80::/64"},
+		},
+		{
+			name: "non-CIDR entries in a pass through unchanged",
+			a:    []string{"10.0.0.0/24", "not-a-cidr"},
+			b:    []string{"10.0.0.128/25"},
+			want: []string{"10.0.0.0/25", "not-a-cidr"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("Diff(%v, %v): %v", tt.a, tt.b, err)
+			}
+			if !reflect.DeepEqual(sortedOrNil(got), sortedOrNil(tt.want)) {
+				t.Errorf("Diff(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []string
+	}{
+		{
+			name: "partial overlap",
+			a:    []string{"10.0.0.0/24"},
+			b:    []string{"10.0.0.128/25"},
+			want: []string{"10.0.0.128/25"},
+		},
+		{
+			name: "no overlap",
+			a:    []string{"10.0.0.0/24"},
+			b:    []string{"8.8.8.0/24"},
+			want: nil,
+		},
+		{
+			name: "identical blocks",
+			a:    []string{"10.0.0.0/24"},
+			b:    []string{"10.0.0.0/24"},
+			want: []string{"10.0.0.0/24"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Intersect(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("Intersect(%v, %v): %v", tt.a, tt.b, err)
+			}
+			if !reflect.DeepEqual(sortedOrNil(got), sortedOrNil(tt.want)) {
+				t.Errorf("Intersect(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcludeMatchesDiff(t *testing.T) {
+	input := []string{"10.0.0.0/24"}
+	holes := []string{"10.0.0.128/25"}
+
+	got, err := Exclude(input, holes)
+	if err != nil {
+		t.Fatalf("Exclude(%v, %v): %v", input, holes, err)
+	}
+	want, err := Diff(input, holes)
+	if err != nil {
+		t.Fatalf("Diff(%v, %v): %v", input, holes, err)
+	}
+	if !reflect.DeepEqual(sortedOrNil(got), sortedOrNil(want)) {
+		t.Errorf("Exclude(%v, %v) = %v, want it to match Diff's %v", input, holes, got, want)
+	}
+}
+
+func TestExcludePreservesPassthrough(t *testing.T) {
+	input := []string{"10.0.0.0/24", "not-a-cidr"}
+	holes := []string{"10.0.0.128/25"}
+
+	got, err := Exclude(input, holes)
+	if err != nil {
+		t.Fatalf("Exclude(%v, %v): %v", input, holes, err)
+	}
+	want := []string{"10.0.0.0/25", "not-a-cidr"}
+	if !reflect.DeepEqual(sortedOrNil(got), sortedOrNil(want)) {
+		t.Errorf("Exclude(%v, %v) = %v, want %v", input, holes, got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package netmerge
+
+import (
+	"math/big"
+	"net"
+)
+
+// trieNode is one node of a plain bitwise binary trie keyed on address
+// bits: unlike a true radix/patricia trie, runs of single-child nodes are
+// not path-compressed, so a sparse prefix still costs one node per bit. A
+// node with isLeaf set marks the end of an inserted prefix; children are
+// indexed by the next bit (0 or 1).
+type trieNode struct {
+	children [2]*trieNode
+	isLeaf   bool
+	cidr     string
+	val      interface{}
+}
+
+// Trie is a bitwise binary trie over IP prefixes, supporting fast
+// containment and longest-prefix-match lookups. IPv4 and IPv6 entries
+// descend from separate fixed-width roots within the same Trie. It is not
+// path-compressed (no radix/patricia merging of single-child runs), so
+// it's simpler than a wireguard-style allowedips trie at the cost of more
+// nodes for sparse prefix sets.
+type Trie struct {
+	root4 *trieNode
+	root6 *trieNode
+}
+
+// NewTrie builds a Trie from an initial set of CIDRs, each associated with
+// val. Use Insert afterwards to add entries with distinct values.
+func NewTrie(cidrs []string, val interface{}) (*Trie, error) {
+	t := &Trie{root4: &trieNode{}, root6: &trieNode{}}
+	for _, c := range cidrs {
+		if err := t.Insert(c, val); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Insert adds cidr to the trie, associating it with val.
+func (t *Trie) Insert(cidr string, val interface{}) error {
+	v, err := cidrToVector(cidr)
+	if err != nil {
+		return err
+	}
+
+	root := t.root4
+	if v.Family == 6 {
+		root = t.root6
+	}
+	bits := bitWidth(v.Family)
+	prefixLen, _ := v.CIDR.Mask.Size()
+
+	node := root
+	for i := 0; i < prefixLen; i++ {
+		bit := v.FirstIP.Bit(bits - 1 - i)
+		if node.children[bit] == nil {
+			node.children[bit] = &trieNode{}
+		}
+		node = node.children[bit]
+	}
+	node.isLeaf = true
+	node.cidr = cidr
+	node.val = val
+	return nil
+}
+
+// Contains reports whether ip falls within any CIDR previously inserted
+// into the trie.
+func (t *Trie) Contains(ip string) bool {
+	_, _, ok := t.LongestPrefixMatch(ip)
+	return ok
+}
+
+// LongestPrefixMatch returns the most specific inserted CIDR that contains
+// ip, its associated value, and whether a match was found.
+func (t *Trie) LongestPrefixMatch(ip string) (cidr string, val interface{}, ok bool) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return "", nil, false
+	}
+
+	family := 4
+	raw := addr.To4()
+	if raw == nil {
+		family = 6
+		raw = addr.To16()
+	}
+	root := t.root4
+	if family == 6 {
+		root = t.root6
+	}
+
+	ipInt := new(big.Int).SetBytes(raw)
+	bits := bitWidth(family)
+
+	var lastMatch *trieNode
+	node := root
+	for i := 0; i < bits && node != nil; i++ {
+		if node.isLeaf {
+			lastMatch = node
+		}
+		bit := ipInt.Bit(bits - 1 - i)
+		node = node.children[bit]
+	}
+	if node != nil && node.isLeaf {
+		lastMatch = node
+	}
+
+	if lastMatch == nil {
+		return "", nil, false
+	}
+	return lastMatch.cidr, lastMatch.val, true
+}
+
+// CoveredBy returns every inserted CIDR that is contained within cidr
+// (equal to it or a sub-block of it), found by descending to cidr's node
+// and collecting every leaf beneath it.
+func (t *Trie) CoveredBy(cidr string) []string {
+	v, err := cidrToVector(cidr)
+	if err != nil {
+		return nil
+	}
+
+	root := t.root4
+	if v.Family == 6 {
+		root = t.root6
+	}
+	bits := bitWidth(v.Family)
+	prefixLen, _ := v.CIDR.Mask.Size()
+
+	node := root
+	for i := 0; i < prefixLen && node != nil; i++ {
+		bit := v.FirstIP.Bit(bits - 1 - i)
+		node = node.children[bit]
+	}
+	if node == nil {
+		return nil
+	}
+
+	var out []string
+	collectLeaves(node, &out)
+	return out
+}
+
+// collectLeaves appends the CIDR of every leaf in the subtree rooted at
+// node to out, in no particular order.
+func collectLeaves(node *trieNode, out *[]string) {
+	if node == nil {
+		return
+	}
+	if node.isLeaf {
+		*out = append(*out, node.cidr)
+	}
+	collectLeaves(node.children[0], out)
+	collectLeaves(node.children[1], out)
+}
@@ -0,0 +1,65 @@
+package netmerge
+
+import "testing"
+
+func TestTrieContainsAndLongestPrefixMatch(t *testing.T) {
+	tr, err := NewTrie([]string{"10.0.0.0/24", "10.0.0.0/16", "fe80::/64"}, "default")
+	if err != nil {
+		t.Fatalf("NewTrie: %v", err)
+	}
+	if err := tr.Insert("10.0.0.128/25", "more-specific"); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	tests := []struct {
+		ip       string
+		wantOK   bool
+		wantCIDR string
+		wantVal  interface{}
+	}{
+		{ip: "10.0.0.200", wantOK: true, wantCIDR: "10.0.0.128/25", wantVal: "more-specific"},
+		{ip: "10.0.0.50", wantOK: true, wantCIDR: "10.0.0.0/24", wantVal: "default"},
+		{ip: "10.0.5.1", wantOK: true, wantCIDR: "10.0.0.0/16", wantVal: "default"},
+		{ip: "fe80::1", wantOK: true, wantCIDR: "fe80::/64", wantVal: "default"},
+		{ip: "192.168.1.1", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		cidr, val, ok := tr.LongestPrefixMatch(tt.ip)
+		if ok != tt.wantOK {
+			t.Errorf("LongestPrefixMatch(%q) ok = %v, want %v", tt.ip, ok, tt.wantOK)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if cidr != tt.wantCIDR || val != tt.wantVal {
+			t.Errorf("LongestPrefixMatch(%q) = (%q, %v), want (%q, %v)", tt.ip, cidr, val, tt.wantCIDR, tt.wantVal)
+		}
+		if got := tr.Contains(tt.ip); got != tt.wantOK {
+			t.Errorf("Contains(%q) = %v, want %v", tt.ip, got, tt.wantOK)
+		}
+	}
+}
+
+func TestTrieCoveredBy(t *testing.T) {
+	tr, err := NewTrie([]string{"10.0.0.0/25", "10.0.0.128/25", "10.1.0.0/24"}, nil)
+	if err != nil {
+		t.Fatalf("NewTrie: %v", err)
+	}
+
+	got := tr.CoveredBy("10.0.0.0/24")
+	want := map[string]bool{"10.0.0.0/25": true, "10.0.0.128/25": true}
+	if len(got) != len(want) {
+		t.Fatalf("CoveredBy(10.0.0.0/24) = %v, want entries for %v", got, want)
+	}
+	for _, c := range got {
+		if !want[c] {
+			t.Errorf("CoveredBy(10.0.0.0/24) returned unexpected %q", c)
+		}
+	}
+
+	if got := tr.CoveredBy("192.168.0.0/24"); got != nil {
+		t.Errorf("CoveredBy(192.168.0.0/24) = %v, want nil", got)
+	}
+}